@@ -1,8 +1,11 @@
 package inbound
 
 import (
+	stdcontext "context"
 	"net"
 
+	"github.com/Dreamacro/clash/adapter/traffic"
+	"github.com/Dreamacro/clash/transport/upstream"
 	C "github.com/btwiuse/dash/constant"
 	"github.com/btwiuse/dash/context"
 	"github.com/btwiuse/dash/transport/socks5"
@@ -17,5 +20,29 @@ func NewHTTP(target socks5.Addr, source net.Addr, conn net.Conn) *context.ConnCo
 		metadata.SrcIP = ip
 		metadata.SrcPort = port
 	}
+	// The matched rule isn't known yet at inbound time, so it's recorded
+	// as nil here; RoutedConnection still gets the durable history entry
+	// started so upload/download accrue from the very first byte.
+	if tc := traffic.DefaultController(); tc != nil {
+		conn = tc.RoutedConnection(stdcontext.Background(), conn, metadata, nil, effectiveUpstream(metadata))
+	}
 	return context.NewConnContext(conn, metadata)
 }
+
+// effectiveUpstream returns the chained proxy URL the globally
+// configured upstream.Default() chain would dial metadata's destination
+// through, or "" for a direct dial. Per-rule via: overrides aren't known
+// yet at inbound time, so this only reflects proxy-chain/no-proxy, not a
+// rule match; it exists so the upstream used shows up in connection
+// history even though the final per-rule choice happens downstream.
+func effectiveUpstream(metadata *C.Metadata) string {
+	chain := upstream.Default()
+	if chain == nil {
+		return ""
+	}
+	up, err := chain.Resolve(metadata.RemoteAddress(), "")
+	if err != nil || up == nil {
+		return ""
+	}
+	return up.Scheme + "://" + up.Addr
+}
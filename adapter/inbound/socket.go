@@ -1,8 +1,10 @@
 package inbound
 
 import (
+	stdcontext "context"
 	"net"
 
+	"github.com/Dreamacro/clash/adapter/traffic"
 	C "github.com/btwiuse/dash/constant"
 	"github.com/btwiuse/dash/context"
 	"github.com/btwiuse/dash/transport/socks5"
@@ -18,5 +20,9 @@ func NewSocket(target socks5.Addr, conn net.Conn, source C.Type) *context.ConnCo
 		metadata.SrcPort = port
 	}
 
+	if tc := traffic.DefaultController(); tc != nil {
+		conn = tc.RoutedConnection(stdcontext.Background(), conn, metadata, nil, effectiveUpstream(metadata))
+	}
+
 	return context.NewConnContext(conn, metadata)
 }
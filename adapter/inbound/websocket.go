@@ -0,0 +1,28 @@
+package inbound
+
+import (
+	stdcontext "context"
+	"net"
+
+	"github.com/Dreamacro/clash/adapter/traffic"
+	C "github.com/btwiuse/dash/constant"
+	"github.com/btwiuse/dash/context"
+	"github.com/btwiuse/dash/transport/socks5"
+)
+
+// NewWebSocket receives a binary WebSocket stream bridged to target and
+// returns a ConnContext, the same way NewSocket/NewHTTP expose SOCKS and
+// HTTP inbounds to the tunnel.
+func NewWebSocket(target socks5.Addr, source net.Addr, conn net.Conn) *context.ConnContext {
+	metadata := parseSocksAddr(target)
+	metadata.NetWork = C.TCP
+	metadata.Type = C.HTTP
+	if ip, port, err := parseAddr(source.String()); err == nil {
+		metadata.SrcIP = ip
+		metadata.SrcPort = port
+	}
+	if tc := traffic.DefaultController(); tc != nil {
+		conn = tc.RoutedConnection(stdcontext.Background(), conn, metadata, nil, effectiveUpstream(metadata))
+	}
+	return context.NewConnContext(conn, metadata)
+}
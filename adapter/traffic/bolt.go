@@ -0,0 +1,267 @@
+package traffic
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	C "github.com/btwiuse/dash/constant"
+
+	"github.com/gofrs/uuid"
+	"go.etcd.io/bbolt"
+)
+
+var historyBucket = []byte("connections_history")
+
+// BoltController is a Controller backed by a bbolt database, so
+// connection history survives process restarts, unlike the in-memory
+// tunnel/statistic.DefaultManager.
+type BoltController struct {
+	db *bbolt.DB
+}
+
+// OpenBoltController opens (creating if necessary) a bbolt database at
+// path to back connection history.
+func OpenBoltController(path string) (*BoltController, error) {
+	db, err := bbolt.Open(path, 0o600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(historyBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltController{db: db}, nil
+}
+
+// Close releases the underlying bbolt database.
+func (b *BoltController) Close() error {
+	return b.db.Close()
+}
+
+// RoutedConnection persists rec immediately, before conn has carried a
+// single byte: a connection still open at process restart is exactly
+// the case durable history exists to cover, and a record only written
+// by meteredConn.Close would be lost just like statistic.DefaultManager's
+// in-memory one for any connection that outlives the process. Close
+// re-persists the same record with its final Upload/Download/End.
+func (b *BoltController) RoutedConnection(ctx context.Context, conn net.Conn, metadata *C.Metadata, rule C.Rule, upstreamURL string) net.Conn {
+	rec := b.newRecord(metadata, rule, upstreamURL)
+	b.persist(rec)
+	return &meteredConn{Conn: conn, record: rec, persist: b.persist}
+}
+
+// RoutedPacketConnection is RoutedConnection's net.PacketConn counterpart.
+func (b *BoltController) RoutedPacketConnection(ctx context.Context, conn net.PacketConn, metadata *C.Metadata, rule C.Rule, upstreamURL string) net.PacketConn {
+	rec := b.newRecord(metadata, rule, upstreamURL)
+	b.persist(rec)
+	return &meteredPacketConn{PacketConn: conn, record: rec, persist: b.persist}
+}
+
+func (b *BoltController) newRecord(metadata *C.Metadata, rule C.Rule, upstreamURL string) *HistoryRecord {
+	id, _ := uuid.NewV4()
+	rec := &HistoryRecord{
+		ID:       id.String(),
+		Metadata: metadata,
+		Upstream: upstreamURL,
+		Start:    time.Now(),
+	}
+	if rule != nil {
+		rec.Rule = rule.RuleType().String()
+		rec.Chains = []string{rule.Adapter()}
+	}
+	return rec
+}
+
+func (b *BoltController) persist(rec *HistoryRecord) {
+	data, err := json.Marshal(toStored(rec))
+	if err != nil {
+		return
+	}
+
+	_ = b.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(historyBucket).Put([]byte(rec.ID), data)
+	})
+}
+
+// History returns every recorded connection that started at or after
+// since, sorted oldest-to-newest by Start: bbolt.ForEach iterates in key
+// (id) order, not Start order, which would otherwise leave a timeline
+// API like GET /connections/history in effectively random order.
+func (b *BoltController) History(since time.Time) ([]HistoryRecord, error) {
+	var records []HistoryRecord
+
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(historyBucket).ForEach(func(_, v []byte) error {
+			var s storedRecord
+			if err := json.Unmarshal(v, &s); err != nil {
+				return nil
+			}
+			rec := s.toRecord()
+			if rec.Start.Before(since) {
+				return nil
+			}
+			records = append(records, *rec)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(records, func(i, j int) bool { return records[i].Start.Before(records[j].Start) })
+	return records, nil
+}
+
+// storedMetadata mirrors C.Metadata for bbolt storage, with NetWork and
+// Type as plain ints: those two fields only implement MarshalJSON
+// upstream, not UnmarshalJSON, so round-tripping a *C.Metadata straight
+// through json.Marshal/json.Unmarshal silently fails to decode and drops
+// the whole record. HistoryRecord's own JSON shape (used for the REST
+// API response) is unaffected; this is purely the on-disk encoding.
+type storedMetadata struct {
+	NetWork     int       `json:"network"`
+	Type        int       `json:"type"`
+	SrcIP       net.IP    `json:"sourceIP"`
+	DstIP       net.IP    `json:"destinationIP"`
+	SrcPort     string    `json:"sourcePort"`
+	DstPort     string    `json:"destinationPort"`
+	Host        string    `json:"host"`
+	DNSMode     C.DNSMode `json:"dnsMode"`
+	ProcessPath string    `json:"processPath"`
+}
+
+// storedRecord mirrors HistoryRecord for bbolt storage, substituting
+// storedMetadata for Metadata.
+type storedRecord struct {
+	ID       string          `json:"id"`
+	Metadata *storedMetadata `json:"metadata"`
+	Rule     string          `json:"rule"`
+	Chains   []string        `json:"chains"`
+	Upstream string          `json:"upstream,omitempty"`
+	Upload   int64           `json:"upload"`
+	Download int64           `json:"download"`
+	Start    time.Time       `json:"start"`
+	End      time.Time       `json:"end,omitempty"`
+}
+
+func toStored(rec *HistoryRecord) *storedRecord {
+	s := &storedRecord{
+		ID:       rec.ID,
+		Rule:     rec.Rule,
+		Chains:   rec.Chains,
+		Upstream: rec.Upstream,
+		Upload:   rec.Upload,
+		Download: rec.Download,
+		Start:    rec.Start,
+		End:      rec.End,
+	}
+	if rec.Metadata != nil {
+		s.Metadata = &storedMetadata{
+			NetWork:     int(rec.Metadata.NetWork),
+			Type:        int(rec.Metadata.Type),
+			SrcIP:       rec.Metadata.SrcIP,
+			DstIP:       rec.Metadata.DstIP,
+			SrcPort:     rec.Metadata.SrcPort,
+			DstPort:     rec.Metadata.DstPort,
+			Host:        rec.Metadata.Host,
+			DNSMode:     rec.Metadata.DNSMode,
+			ProcessPath: rec.Metadata.ProcessPath,
+		}
+	}
+	return s
+}
+
+func (s *storedRecord) toRecord() *HistoryRecord {
+	rec := &HistoryRecord{
+		ID:       s.ID,
+		Rule:     s.Rule,
+		Chains:   s.Chains,
+		Upstream: s.Upstream,
+		Upload:   s.Upload,
+		Download: s.Download,
+		Start:    s.Start,
+		End:      s.End,
+	}
+	if s.Metadata != nil {
+		rec.Metadata = &C.Metadata{
+			NetWork:     C.NetWork(s.Metadata.NetWork),
+			Type:        C.Type(s.Metadata.Type),
+			SrcIP:       s.Metadata.SrcIP,
+			DstIP:       s.Metadata.DstIP,
+			SrcPort:     s.Metadata.SrcPort,
+			DstPort:     s.Metadata.DstPort,
+			Host:        s.Metadata.Host,
+			DNSMode:     s.Metadata.DNSMode,
+			ProcessPath: s.Metadata.ProcessPath,
+		}
+	}
+	return rec
+}
+
+type meteredConn struct {
+	net.Conn
+	record   *HistoryRecord
+	persist  func(*HistoryRecord)
+	upload   int64
+	download int64
+}
+
+func (c *meteredConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	atomic.AddInt64(&c.download, int64(n))
+	return n, err
+}
+
+func (c *meteredConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	atomic.AddInt64(&c.upload, int64(n))
+	return n, err
+}
+
+func (c *meteredConn) Close() error {
+	err := c.Conn.Close()
+	c.record.End = time.Now()
+	c.record.Upload = atomic.LoadInt64(&c.upload)
+	c.record.Download = atomic.LoadInt64(&c.download)
+	c.persist(c.record)
+	return err
+}
+
+type meteredPacketConn struct {
+	net.PacketConn
+	record   *HistoryRecord
+	persist  func(*HistoryRecord)
+	upload   int64
+	download int64
+}
+
+func (c *meteredPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	n, addr, err := c.PacketConn.ReadFrom(b)
+	atomic.AddInt64(&c.download, int64(n))
+	return n, addr, err
+}
+
+func (c *meteredPacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	n, err := c.PacketConn.WriteTo(b, addr)
+	atomic.AddInt64(&c.upload, int64(n))
+	return n, err
+}
+
+func (c *meteredPacketConn) Close() error {
+	err := c.PacketConn.Close()
+	c.record.End = time.Now()
+	c.record.Upload = atomic.LoadInt64(&c.upload)
+	c.record.Download = atomic.LoadInt64(&c.download)
+	c.persist(c.record)
+	return err
+}
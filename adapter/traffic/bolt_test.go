@@ -0,0 +1,207 @@
+package traffic
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	C "github.com/btwiuse/dash/constant"
+)
+
+type fakeRule struct {
+	ruleType C.RuleType
+	adapter  string
+}
+
+func (r fakeRule) RuleType() C.RuleType     { return r.ruleType }
+func (r fakeRule) Match(_ *C.Metadata) bool { return true }
+func (r fakeRule) Adapter() string          { return r.adapter }
+func (r fakeRule) Payload() string          { return "" }
+func (r fakeRule) ShouldResolveIP() bool    { return false }
+func (r fakeRule) ShouldFindProcess() bool  { return false }
+
+// tcpPipe returns a connected client/server pair of loopback TCP
+// connections. Unlike net.Pipe, writes don't block on a concurrent
+// reader, which suits a test that writes then immediately closes.
+func tcpPipe(t *testing.T) (client, server net.Conn) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, _ := ln.Accept()
+		accepted <- conn
+	}()
+
+	client, err = net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	server = <-accepted
+	return client, server
+}
+
+func TestBoltControllerHistoryRoundTrip(t *testing.T) {
+	db, err := OpenBoltController(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("OpenBoltController: %v", err)
+	}
+	defer db.Close()
+
+	client, server := tcpPipe(t)
+	defer server.Close()
+
+	metadata := &C.Metadata{Host: "example.com", DstPort: "443"}
+	rule := fakeRule{ruleType: C.Domain, adapter: "Proxy"}
+
+	wrapped := db.RoutedConnection(context.Background(), client, metadata, rule, "socks5://10.0.0.1:1080")
+	if _, err := wrapped.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := wrapped.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	records, err := db.History(time.Time{})
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+
+	rec := records[0]
+	if rec.Metadata == nil || rec.Metadata.Host != "example.com" {
+		t.Errorf("Metadata = %+v, want Host example.com", rec.Metadata)
+	}
+	if rec.Rule != "Domain" {
+		t.Errorf("Rule = %q, want Domain", rec.Rule)
+	}
+	if len(rec.Chains) != 1 || rec.Chains[0] != "Proxy" {
+		t.Errorf("Chains = %v, want [Proxy]", rec.Chains)
+	}
+	if rec.Upstream != "socks5://10.0.0.1:1080" {
+		t.Errorf("Upstream = %q, want socks5://10.0.0.1:1080", rec.Upstream)
+	}
+	if rec.Upload != 5 {
+		t.Errorf("Upload = %d, want 5", rec.Upload)
+	}
+	if rec.End.IsZero() {
+		t.Error("End time not recorded")
+	}
+}
+
+// TestBoltControllerPersistsBeforeClose is a regression test for a
+// restart-durability bug: a record was only written to bbolt from
+// meteredConn.Close, so a connection still open at process restart -
+// exactly the case durable history exists to cover - was never
+// persisted and lost just like statistic.DefaultManager's in-memory
+// view. RoutedConnection must persist a start record up front.
+func TestBoltControllerPersistsBeforeClose(t *testing.T) {
+	db, err := OpenBoltController(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("OpenBoltController: %v", err)
+	}
+	defer db.Close()
+
+	client, server := tcpPipe(t)
+	defer client.Close()
+	defer server.Close()
+
+	metadata := &C.Metadata{Host: "example.com", DstPort: "443"}
+	wrapped := db.RoutedConnection(context.Background(), client, metadata, nil, "")
+
+	records, err := db.History(time.Time{})
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("got %d records before Close, want 1", len(records))
+	}
+	if records[0].Metadata == nil || records[0].Metadata.Host != "example.com" {
+		t.Errorf("Metadata = %+v, want Host example.com", records[0].Metadata)
+	}
+	if !records[0].End.IsZero() {
+		t.Error("End time recorded before Close")
+	}
+
+	_ = wrapped.Close()
+}
+
+// TestBoltControllerHistorySortedByStart is a regression test for
+// History returning records in bbolt key (UUID) order instead of Start
+// order: GET /connections/history?since=... is a timeline API and
+// callers expect it sorted, but a UUID has no relationship to the
+// order its record was persisted in.
+func TestBoltControllerHistorySortedByStart(t *testing.T) {
+	db, err := OpenBoltController(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("OpenBoltController: %v", err)
+	}
+	defer db.Close()
+
+	newest := db.newRecord(&C.Metadata{Host: "newest.example.com"}, nil, "")
+	newest.Start = time.Now()
+	oldest := db.newRecord(&C.Metadata{Host: "oldest.example.com"}, nil, "")
+	oldest.Start = newest.Start.Add(-time.Hour)
+	middle := db.newRecord(&C.Metadata{Host: "middle.example.com"}, nil, "")
+	middle.Start = newest.Start.Add(-30 * time.Minute)
+
+	// Persist out of Start order so a UUID-keyed iteration wouldn't
+	// happen to come back sorted by coincidence.
+	db.persist(newest)
+	db.persist(oldest)
+	db.persist(middle)
+
+	records, err := db.History(time.Time{})
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3", len(records))
+	}
+
+	wantOrder := []string{"oldest.example.com", "middle.example.com", "newest.example.com"}
+	for i, want := range wantOrder {
+		if records[i].Metadata.Host != want {
+			t.Errorf("records[%d].Metadata.Host = %q, want %q", i, records[i].Metadata.Host, want)
+		}
+	}
+}
+
+// TestBoltControllerMetadataTypeFieldsRoundTrip guards against a silent
+// data-loss bug in storage: C.Metadata's NetWork and Type fields only
+// implement MarshalJSON upstream, not UnmarshalJSON, so a naive
+// json.Marshal/Unmarshal of the whole record failed to decode and
+// dropped every record from History.
+func TestBoltControllerMetadataTypeFieldsRoundTrip(t *testing.T) {
+	db, err := OpenBoltController(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("OpenBoltController: %v", err)
+	}
+	defer db.Close()
+
+	metadata := &C.Metadata{NetWork: C.UDP, Type: C.SOCKS5, Host: "example.com"}
+	db.persist(db.newRecord(metadata, nil, ""))
+
+	records, err := db.History(time.Time{})
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+	if records[0].Metadata.NetWork != C.UDP {
+		t.Errorf("NetWork = %v, want %v", records[0].Metadata.NetWork, C.UDP)
+	}
+	if records[0].Metadata.Type != C.SOCKS5 {
+		t.Errorf("Type = %v, want %v", records[0].Metadata.Type, C.SOCKS5)
+	}
+}
@@ -0,0 +1,62 @@
+// Package traffic hooks connection routing the way sing-box's traffic
+// controller does: every inbound connection that's handed to the tunnel
+// can be wrapped to record start time, matched rule, chosen outbound and
+// cumulative up/down bytes, independent of the in-memory
+// tunnel/statistic.DefaultManager used for the live /connections view.
+package traffic
+
+import (
+	"context"
+	"net"
+	"time"
+
+	C "github.com/btwiuse/dash/constant"
+)
+
+// Controller records routing decisions and byte counters for connections
+// handed off to it. Implementations are free to keep this in memory or,
+// like BoltController, persist it so history survives a restart.
+type Controller interface {
+	// RoutedConnection wraps conn, recording it as having matched rule
+	// (nil if none matched, e.g. the final catch-all) and dialed through
+	// upstreamURL (empty for a direct dial). The returned net.Conn must
+	// be used in place of conn from this point on.
+	RoutedConnection(ctx context.Context, conn net.Conn, metadata *C.Metadata, rule C.Rule, upstreamURL string) net.Conn
+
+	// RoutedPacketConnection is the net.PacketConn equivalent of
+	// RoutedConnection, for UDP-based outbounds.
+	RoutedPacketConnection(ctx context.Context, conn net.PacketConn, metadata *C.Metadata, rule C.Rule, upstreamURL string) net.PacketConn
+}
+
+// HistoryRecord is one durable connection record, the JSON shape exposed
+// by GET /connections/history.
+type HistoryRecord struct {
+	ID       string      `json:"id"`
+	Metadata *C.Metadata `json:"metadata"`
+	Rule     string      `json:"rule"`
+	Chains   []string    `json:"chains"`
+	// Upstream is the chained HTTP/SOCKS5 proxy URL (see
+	// transport/upstream) that the global chain resolves for the
+	// connection's destination, empty when it resolves to a direct dial.
+	// Only /tunnel connections are dialed through it; SOCKS/HTTP inbound
+	// connections are labelled with it but dialed without it.
+	Upstream string    `json:"upstream,omitempty"`
+	Upload   int64     `json:"upload"`
+	Download int64     `json:"download"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end,omitempty"`
+}
+
+var defaultController Controller
+
+// DefaultController returns the Controller registered via
+// SetDefaultController, or nil if none has been configured.
+func DefaultController() Controller {
+	return defaultController
+}
+
+// SetDefaultController registers the Controller that NewSocket/NewHTTP
+// route new inbound connections through.
+func SetDefaultController(c Controller) {
+	defaultController = c
+}
@@ -0,0 +1,33 @@
+// Command authtoken mints HS256 JWTs for the RESTful API, for
+// deployments that use component/auth.JWTAuthenticator instead of the
+// legacy shared secret.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Dreamacro/clash/component/auth"
+)
+
+func main() {
+	key := flag.String("key", "", "HMAC signing key shared with the controller (required)")
+	subject := flag.String("sub", "", "token subject, recorded in the \"sub\" claim")
+	scopes := flag.String("scopes", auth.ScopeAll, "comma-separated scopes to grant, e.g. configs:read,proxies:write")
+	ttl := flag.Duration("ttl", 24*time.Hour, "token validity duration")
+	flag.Parse()
+
+	if *key == "" {
+		log.Fatalln("authtoken: -key is required")
+	}
+
+	token, err := auth.IssueHS256([]byte(*key), *subject, strings.Split(*scopes, ","), *ttl)
+	if err != nil {
+		log.Fatalln("authtoken:", err)
+	}
+
+	fmt.Println(token)
+}
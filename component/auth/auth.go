@@ -0,0 +1,65 @@
+// Package auth provides pluggable verification of RESTful API callers:
+// a legacy shared-secret bearer token and JWT bearer tokens carrying
+// per-scope permissions.
+package auth
+
+import (
+	"errors"
+	"time"
+)
+
+// Scopes recognised by the RESTful API route groups.
+const (
+	ScopeConfigsRead     = "configs:read"
+	ScopeConfigsWrite    = "configs:write"
+	ScopeProxiesRead     = "proxies:read"
+	ScopeProxiesWrite    = "proxies:write"
+	ScopeRulesRead       = "rules:read"
+	ScopeConnectionsRead = "connections:read"
+	ScopeConnectionsKill = "connections:kill"
+	ScopeProvidersRead   = "providers:read"
+	ScopeLogsRead        = "logs:read"
+	ScopeTrafficRead     = "traffic:read"
+	// ScopeTunnelConnect guards /tunnel/{host}/{port}: a live binary
+	// tunnel into the proxy engine, equivalent to unrestricted network
+	// egress, so it isn't folded into ScopeProxiesRead's read-only
+	// listing/lookup routes.
+	ScopeTunnelConnect = "tunnel:connect"
+	// ScopeAll grants every scope above; used by the legacy shared-secret
+	// authenticator, which predates per-scope tokens.
+	ScopeAll = "*"
+)
+
+// ErrUnauthorized is returned by SecretAuthenticator when the supplied
+// token is missing or doesn't match the secret. JWTAuthenticator returns
+// its own, more specific errors instead.
+var ErrUnauthorized = errors.New("auth: invalid or missing token")
+
+// Claims describes the authenticated caller extracted from a token.
+type Claims struct {
+	Subject   string    `json:"sub"`
+	ExpiresAt time.Time `json:"exp"`
+	Scopes    []string  `json:"scopes"`
+}
+
+// HasScope reports whether the claims grant scope, honouring the
+// ScopeAll wildcard used by legacy tokens.
+func (c *Claims) HasScope(scope string) bool {
+	if c == nil {
+		return false
+	}
+	for _, s := range c.Scopes {
+		if s == ScopeAll || s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator verifies a bearer token extracted from a request and
+// returns the claims it carries.
+type Authenticator interface {
+	// Authenticate verifies token and returns the caller's claims, or a
+	// non-nil error if the token is invalid.
+	Authenticate(token string) (*Claims, error)
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHS256RoundTrip(t *testing.T) {
+	key := []byte("test-signing-key")
+	token, err := IssueHS256(key, "alice", []string{ScopeConfigsRead}, time.Hour)
+	if err != nil {
+		t.Fatalf("IssueHS256: %v", err)
+	}
+
+	claims, err := NewHS256Authenticator(key).Authenticate(token)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if claims.Subject != "alice" {
+		t.Errorf("Subject = %q, want %q", claims.Subject, "alice")
+	}
+	if !claims.HasScope(ScopeConfigsRead) {
+		t.Errorf("claims missing scope %q", ScopeConfigsRead)
+	}
+	if claims.HasScope(ScopeConfigsWrite) {
+		t.Errorf("claims unexpectedly granted scope %q", ScopeConfigsWrite)
+	}
+}
+
+func TestHS256WrongKeyRejected(t *testing.T) {
+	token, err := IssueHS256([]byte("right-key"), "alice", []string{ScopeAll}, time.Hour)
+	if err != nil {
+		t.Fatalf("IssueHS256: %v", err)
+	}
+
+	if _, err := NewHS256Authenticator([]byte("wrong-key")).Authenticate(token); err != errBadSignature {
+		t.Errorf("Authenticate with wrong key: got err %v, want %v", err, errBadSignature)
+	}
+}
+
+func TestHS256ExpiredTokenRejected(t *testing.T) {
+	token, err := IssueHS256([]byte("key"), "alice", []string{ScopeAll}, -time.Minute)
+	if err != nil {
+		t.Fatalf("IssueHS256: %v", err)
+	}
+
+	if _, err := NewHS256Authenticator([]byte("key")).Authenticate(token); err != errTokenExpired {
+		t.Errorf("Authenticate with expired token: got err %v, want %v", err, errTokenExpired)
+	}
+}
+
+func TestMalformedTokenRejected(t *testing.T) {
+	if _, err := NewHS256Authenticator([]byte("key")).Authenticate("not-a-jwt"); err != errMalformedToken {
+		t.Errorf("Authenticate with malformed token: got err %v, want %v", err, errMalformedToken)
+	}
+}
@@ -0,0 +1,23 @@
+package auth
+
+// SecretAuthenticator implements the legacy shared-secret bearer token
+// scheme: a single static string compared against the request token.
+// A matching token is granted every scope, since the shared secret
+// predates per-scope tokens and controllers relying on it expect full
+// access.
+type SecretAuthenticator struct {
+	secret string
+}
+
+// NewSecretAuthenticator returns an Authenticator backed by a static
+// shared secret.
+func NewSecretAuthenticator(secret string) *SecretAuthenticator {
+	return &SecretAuthenticator{secret: secret}
+}
+
+func (a *SecretAuthenticator) Authenticate(token string) (*Claims, error) {
+	if a.secret == "" || token != a.secret {
+		return nil, ErrUnauthorized
+	}
+	return &Claims{Subject: "legacy", Scopes: []string{ScopeAll}}, nil
+}
@@ -0,0 +1,141 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Dreamacro/clash/component/auth"
+	listenerauth "github.com/Dreamacro/clash/listener/auth"
+)
+
+// TestRequireScopeEnforcesClaims is a regression test for the chunk0-2
+// auth bypass: listener/auth and hub/route must agree on a single
+// Authenticator/Claims definition, or ClaimsFromContext always sees nil
+// and requireScope treats every caller as unrestricted.
+func TestRequireScopeEnforcesClaims(t *testing.T) {
+	key := []byte("test-key")
+	listenerauth.SetAuthenticator(auth.NewHS256Authenticator(key))
+	defer listenerauth.SetAuthenticator(nil)
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := authentication(requireScope(auth.ScopeConfigsWrite)(ok))
+
+	token, err := auth.IssueHS256(key, "reader", []string{auth.ScopeConfigsRead}, time.Hour)
+	if err != nil {
+		t.Fatalf("IssueHS256: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/configs", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("token without %s scope: got status %d, want %d", auth.ScopeConfigsWrite, rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestRequireScopeAllowsGrantedScope(t *testing.T) {
+	key := []byte("test-key")
+	listenerauth.SetAuthenticator(auth.NewHS256Authenticator(key))
+	defer listenerauth.SetAuthenticator(nil)
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := authentication(requireScope(auth.ScopeConfigsWrite)(ok))
+
+	token, err := auth.IssueHS256(key, "writer", []string{auth.ScopeConfigsWrite}, time.Hour)
+	if err != nil {
+		t.Fatalf("IssueHS256: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/configs", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("token with %s scope: got status %d, want %d", auth.ScopeConfigsWrite, rec.Code, http.StatusOK)
+	}
+}
+
+// TestRequireScopeInlineAllowsWriteOnlyToken is a regression test for the
+// chunk0-3 double-scope bug: /configs, /proxies and /connections mix read
+// and write routes, so they must not be mounted behind a blanket
+// requireScope(<read scope>) that would 403 a token minted with only the
+// matching write scope (e.g. connections:kill) before its own
+// requireScopeInline check ever runs.
+func TestRequireScopeInlineAllowsWriteOnlyToken(t *testing.T) {
+	key := []byte("test-key")
+	listenerauth.SetAuthenticator(auth.NewHS256Authenticator(key))
+	defer listenerauth.SetAuthenticator(nil)
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !requireScopeInline(w, r, auth.ScopeConnectionsKill) {
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	// No blanket requireScope(ScopeConnectionsRead) wrapper: mixed
+	// read/write groups gate only in the handler, not at the mount.
+	handler := authentication(ok)
+
+	token, err := auth.IssueHS256(key, "killer", []string{auth.ScopeConnectionsKill}, time.Hour)
+	if err != nil {
+		t.Fatalf("IssueHS256: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/connections/abc", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("token with only %s scope: got status %d, want %d", auth.ScopeConnectionsKill, rec.Code, http.StatusOK)
+	}
+}
+
+// TestBearerTokenFindsTokenAmongOtherSubprotocols is a regression test
+// for a bug where bearerToken only recognized "bearer" as the first
+// token in Sec-WebSocket-Protocol. /tunnel negotiates a "binary" data
+// subprotocol on the same header while also requiring bearer auth, so a
+// client sending "Sec-WebSocket-Protocol: binary, bearer, <token>" (to
+// get both subprotocol negotiation and a token that never leaks into a
+// URL or access log) was rejected even with a valid token.
+func TestBearerTokenFindsTokenAmongOtherSubprotocols(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/tunnel", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Protocol", "binary, bearer, test-token")
+
+	token, ok := bearerToken(req)
+	if !ok {
+		t.Fatal("bearerToken did not find a token")
+	}
+	if token != "test-token" {
+		t.Fatalf("token = %q, want %q", token, "test-token")
+	}
+}
+
+func TestAuthenticationRejectsMissingToken(t *testing.T) {
+	listenerauth.SetAuthenticator(auth.NewHS256Authenticator([]byte("test-key")))
+	defer listenerauth.SetAuthenticator(nil)
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := authentication(ok)
+
+	req := httptest.NewRequest(http.MethodGet, "/configs", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("missing token: got status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,114 @@
+package route
+
+import (
+	"net/http"
+
+	"github.com/Dreamacro/clash/component/auth"
+	C "github.com/Dreamacro/clash/constant"
+	"github.com/Dreamacro/clash/log"
+	"github.com/Dreamacro/clash/transport/upstream"
+	"github.com/Dreamacro/clash/tunnel"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func configRouter() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", getConfigs)
+	r.Patch("/", patchConfigs)
+	return r
+}
+
+type configSchema struct {
+	Mode       *string `json:"mode"`
+	LogLevel   *string `json:"log-level"`
+	AllowLan   *bool   `json:"allow-lan"`
+	ProxyChain *string `json:"proxy-chain"`
+	NoProxy    *string `json:"no-proxy"`
+}
+
+func getConfigs(w http.ResponseWriter, r *http.Request) {
+	if !requireScopeInline(w, r, auth.ScopeConfigsRead) {
+		return
+	}
+
+	proxyChain := ""
+	if chain := upstream.Default(); chain != nil && chain.Default != nil {
+		proxyChain = chain.Default.Scheme + "://" + chain.Default.Addr
+	}
+
+	render.JSON(w, r, render.M{
+		"mode":        tunnel.Mode().String(),
+		"log-level":   log.Level().String(),
+		"allow-lan":   tunnel.AllowLan(),
+		"proxy-chain": proxyChain,
+	})
+}
+
+// patchConfigs hot-swaps mode, log-level, allow-lan and the upstream
+// proxy-chain without a config file reload, for dashboards that toggle
+// them at runtime.
+func patchConfigs(w http.ResponseWriter, r *http.Request) {
+	if !requireScopeInline(w, r, auth.ScopeConfigsWrite) {
+		return
+	}
+
+	req := configSchema{}
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, ErrBadRequest)
+		return
+	}
+
+	if req.Mode != nil {
+		mode, err := C.ParseTunnelMode(*req.Mode)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, newError(err.Error()))
+			return
+		}
+		tunnel.SetMode(mode)
+	}
+
+	if req.LogLevel != nil {
+		level, ok := log.LogLevelMapping[*req.LogLevel]
+		if !ok {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, ErrBadRequest)
+			return
+		}
+		log.SetLevel(level)
+	}
+
+	if req.AllowLan != nil {
+		tunnel.SetAllowLan(*req.AllowLan)
+	}
+
+	if req.ProxyChain != nil || req.NoProxy != nil {
+		defaultURL := ""
+		noProxy := ""
+		if chain := upstream.Default(); chain != nil {
+			if chain.Default != nil {
+				defaultURL = chain.Default.Scheme + "://" + chain.Default.Addr
+			}
+			noProxy = chain.NoProxy.String()
+		}
+		if req.ProxyChain != nil {
+			defaultURL = *req.ProxyChain
+		}
+		if req.NoProxy != nil {
+			noProxy = *req.NoProxy
+		}
+
+		chain, err := upstream.NewChain(defaultURL, noProxy)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, newError(err.Error()))
+			return
+		}
+		upstream.SetDefault(chain)
+	}
+
+	render.NoContent(w, r)
+}
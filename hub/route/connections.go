@@ -0,0 +1,108 @@
+package route
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Dreamacro/clash/adapter/traffic"
+	"github.com/Dreamacro/clash/component/auth"
+	"github.com/Dreamacro/clash/tunnel/statistic"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func connectionRouter() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", getConnections)
+	r.Get("/history", getConnectionsHistory)
+	r.Delete("/", closeAllConnections)
+	r.Delete("/{id}", closeConnection)
+	return r
+}
+
+func getConnections(w http.ResponseWriter, r *http.Request) {
+	if !requireScopeInline(w, r, auth.ScopeConnectionsRead) {
+		return
+	}
+	snapshot := statistic.DefaultManager.Snapshot()
+	render.JSON(w, r, snapshot)
+}
+
+// closeAllConnections and closeConnection back DELETE /connections and
+// DELETE /connections/{id}, letting dashboards kill tracked connections
+// the way Clash's own /connections API does.
+func closeAllConnections(w http.ResponseWriter, r *http.Request) {
+	if !allowKill(w, r) {
+		return
+	}
+
+	statistic.DefaultManager.Range(func(c statistic.Tracker) bool {
+		_ = c.Close()
+		return true
+	})
+	render.NoContent(w, r)
+}
+
+func closeConnection(w http.ResponseWriter, r *http.Request) {
+	if !allowKill(w, r) {
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	c, ok := statistic.DefaultManager.Get(id)
+	if !ok {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, ErrNotFound)
+		return
+	}
+
+	_ = c.Close()
+	render.NoContent(w, r)
+}
+
+// historyStore is implemented by Controllers that persist records, such
+// as traffic.BoltController; in-memory-only Controllers don't satisfy it.
+type historyStore interface {
+	History(since time.Time) ([]traffic.HistoryRecord, error)
+}
+
+// getConnectionsHistory serves durable connection records recorded by
+// the configured traffic.Controller, unlike GET /connections which only
+// reflects statistic.DefaultManager's in-memory, restart-losing view.
+func getConnectionsHistory(w http.ResponseWriter, r *http.Request) {
+	if !requireScopeInline(w, r, auth.ScopeConnectionsRead) {
+		return
+	}
+
+	store, ok := traffic.DefaultController().(historyStore)
+	if !ok {
+		render.Status(r, http.StatusNotImplemented)
+		render.JSON(w, r, newError("no durable traffic controller configured"))
+		return
+	}
+
+	since := time.Time{}
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, newError("since must be RFC3339"))
+			return
+		}
+		since = parsed
+	}
+
+	records, err := store.History(since)
+	if err != nil {
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, newError(err.Error()))
+		return
+	}
+
+	render.JSON(w, r, render.M{"connections": records})
+}
+
+func allowKill(w http.ResponseWriter, r *http.Request) bool {
+	return requireScopeInline(w, r, auth.ScopeConnectionsKill)
+}
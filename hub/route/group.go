@@ -0,0 +1,86 @@
+package route
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Dreamacro/clash/adapter/outboundgroup"
+	"github.com/Dreamacro/clash/tunnel"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func groupRouter() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/{name}/delay", getGroupDelay)
+	return r
+}
+
+// getGroupDelay runs a delay test against every member of the named
+// proxy group concurrently, bounded by a worker pool sized to the host
+// so a large group doesn't dial out hundreds of connections at once.
+func getGroupDelay(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	proxy, exist := tunnel.Proxies()[name]
+	if !exist {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, ErrNotFound)
+		return
+	}
+
+	group, ok := proxy.(*outboundgroup.Group)
+	if !ok {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, newError("proxy is not a group"))
+		return
+	}
+
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, newError("url is required"))
+		return
+	}
+
+	timeout, err := strconv.ParseInt(r.URL.Query().Get("timeout"), 10, 32)
+	if err != nil || timeout <= 0 {
+		timeout = 5000
+	}
+
+	members := group.Proxies()
+	results := make(map[string]uint16, len(members))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, runtime.NumCPU())
+
+	for _, member := range members {
+		member := member
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			ctx, cancel := context.WithTimeout(r.Context(), time.Duration(timeout)*time.Millisecond)
+			defer cancel()
+
+			delay, _, err := member.URLTest(ctx, url)
+			if err != nil {
+				delay = 0
+			}
+
+			mu.Lock()
+			results[member.Name()] = delay
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	render.JSON(w, r, results)
+}
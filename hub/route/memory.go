@@ -0,0 +1,75 @@
+package route
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/gorilla/websocket"
+)
+
+type memoryUsage struct {
+	InUse      uint64 `json:"inuse"`
+	Goroutines int    `json:"goroutines"`
+}
+
+// memory samples the Go runtime's in-use heap and stack memory (not the
+// process RSS) and the goroutine count every second, streamed the same
+// three ways as /traffic and /logs: plain newline-delimited JSON,
+// WebSocket, or SSE for browsers behind proxies that break WebSocket.
+func memory(w http.ResponseWriter, r *http.Request) {
+	var wsConn *websocket.Conn
+	if websocket.IsWebSocketUpgrade(r) {
+		var err error
+		wsConn, err = upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+	}
+
+	sse := wsConn == nil && isSSE(r)
+
+	if wsConn == nil {
+		if sse {
+			w.Header().Set("Content-Type", "text/event-stream")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		render.Status(r, http.StatusOK)
+	}
+
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+
+	buf := &bytes.Buffer{}
+	var stats runtime.MemStats
+	var err error
+	for range tick.C {
+		runtime.ReadMemStats(&stats)
+
+		buf.Reset()
+		if err := json.NewEncoder(buf).Encode(memoryUsage{
+			InUse:      stats.HeapInuse + stats.StackInuse,
+			Goroutines: runtime.NumGoroutine(),
+		}); err != nil {
+			break
+		}
+
+		switch {
+		case wsConn != nil:
+			err = wsConn.WriteMessage(websocket.TextMessage, buf.Bytes())
+		case sse:
+			err = writeSSE(w, buf.Bytes())
+		default:
+			_, err = w.Write(buf.Bytes())
+			w.(http.Flusher).Flush()
+		}
+
+		if err != nil {
+			break
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package route
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Dreamacro/clash/adapter/outboundgroup"
+	"github.com/Dreamacro/clash/component/auth"
+	C "github.com/Dreamacro/clash/constant"
+	"github.com/Dreamacro/clash/tunnel"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func proxyRouter() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", getProxies)
+	r.Route("/{name}", func(r chi.Router) {
+		r.Use(parseProxyFromCtx)
+		r.Get("/", getProxy)
+		r.Put("/", updateProxy)
+		r.Get("/delay", getProxyDelay)
+	})
+	return r
+}
+
+func getProxies(w http.ResponseWriter, r *http.Request) {
+	if !requireScopeInline(w, r, auth.ScopeProxiesRead) {
+		return
+	}
+	render.JSON(w, r, render.M{"proxies": tunnel.Proxies()})
+}
+
+func parseProxyFromCtx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+		proxy, exist := tunnel.Proxies()[name]
+		if !exist {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), ctxKeyProxy, proxy)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getProxy(w http.ResponseWriter, r *http.Request) {
+	if !requireScopeInline(w, r, auth.ScopeProxiesRead) {
+		return
+	}
+	proxy := r.Context().Value(ctxKeyProxy).(C.Proxy)
+	render.JSON(w, r, proxy)
+}
+
+type updateProxyRequest struct {
+	Name string `json:"name"`
+}
+
+// updateProxy switches a Selector's active member, the write half of the
+// Clash-compatible control surface (GET only changed nothing before).
+func updateProxy(w http.ResponseWriter, r *http.Request) {
+	if !requireScopeInline(w, r, auth.ScopeProxiesWrite) {
+		return
+	}
+
+	proxy := r.Context().Value(ctxKeyProxy).(C.Proxy)
+	selector, ok := proxy.(*outboundgroup.Selector)
+	if !ok {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, newError("proxy is not a Selector"))
+		return
+	}
+
+	req := updateProxyRequest{}
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, ErrBadRequest)
+		return
+	}
+
+	if err := selector.Set(req.Name); err != nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, newError(err.Error()))
+		return
+	}
+
+	render.NoContent(w, r)
+}
+
+type delayResult struct {
+	Delay     uint16 `json:"delay"`
+	MeanDelay uint16 `json:"meanDelay"`
+}
+
+// getProxyDelay benchmarks proxy by dialing url through it, the same
+// check dashboards use to sort proxies by latency.
+func getProxyDelay(w http.ResponseWriter, r *http.Request) {
+	if !requireScopeInline(w, r, auth.ScopeProxiesRead) {
+		return
+	}
+
+	proxy := r.Context().Value(ctxKeyProxy).(C.Proxy)
+
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, newError("url is required"))
+		return
+	}
+
+	timeout, err := strconv.ParseInt(r.URL.Query().Get("timeout"), 10, 32)
+	if err != nil || timeout <= 0 {
+		timeout = 5000
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(timeout)*time.Millisecond)
+	defer cancel()
+
+	delay, meanDelay, err := proxy.URLTest(ctx, url)
+	if err != nil || delay == 0 {
+		render.Status(r, http.StatusServiceUnavailable)
+		render.JSON(w, r, newError("An error occurred in the delay test"))
+		return
+	}
+
+	render.JSON(w, r, delayResult{Delay: delay, MeanDelay: meanDelay})
+}
@@ -0,0 +1,20 @@
+package route
+
+import (
+	"net/http"
+
+	"github.com/Dreamacro/clash/tunnel"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func proxyProviderRouter() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", getProxyProviders)
+	return r
+}
+
+func getProxyProviders(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, render.M{"providers": tunnel.ProxyProviders()})
+}
@@ -0,0 +1,36 @@
+package route
+
+import (
+	"net/http"
+
+	"github.com/Dreamacro/clash/tunnel"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func ruleRouter() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", getRules)
+	return r
+}
+
+type rule struct {
+	Type    string `json:"type"`
+	Payload string `json:"payload"`
+	Proxy   string `json:"proxy"`
+}
+
+func getRules(w http.ResponseWriter, r *http.Request) {
+	rawRules := tunnel.Rules()
+	rules := make([]rule, 0, len(rawRules))
+	for _, rl := range rawRules {
+		rules = append(rules, rule{
+			Type:    rl.RuleType().String(),
+			Payload: rl.Payload(),
+			Proxy:   rl.Adapter(),
+		})
+	}
+
+	render.JSON(w, r, render.M{"rules": rules})
+}
@@ -8,7 +8,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Dreamacro/clash/component/auth"
 	C "github.com/Dreamacro/clash/constant"
+	listenerauth "github.com/Dreamacro/clash/listener/auth"
 	"github.com/Dreamacro/clash/log"
 	"github.com/Dreamacro/clash/tunnel/statistic"
 
@@ -22,8 +24,7 @@ import (
 )
 
 var (
-	serverSecret = ""
-	serverAddr   = ""
+	serverAddr = ""
 
 	uiPath = ""
 
@@ -43,13 +44,19 @@ func SetUIPath(path string) {
 	uiPath = path
 }
 
+// Start launches the RESTful API. secret, if non-empty and no
+// Authenticator has been configured via listener/auth.SetAuthenticator,
+// is wired up as the legacy shared-secret Authenticator so existing
+// callers of Start keep working unchanged.
 func Start(addr string, secret string) {
 	if serverAddr != "" {
 		return
 	}
 
 	serverAddr = addr
-	serverSecret = secret
+	if secret != "" && listenerauth.Authenticator() == nil {
+		listenerauth.SetAuthenticator(auth.NewSecretAuthenticator(secret))
+	}
 
 	r := chi.NewRouter()
 
@@ -64,14 +71,21 @@ func Start(addr string, secret string) {
 	r.Group(func(r chi.Router) {
 		r.Use(authentication)
 
-		r.Get("/logs", getLogs)
-		r.Get("/traffic", traffic)
+		r.With(requireScope(auth.ScopeLogsRead)).Get("/logs", getLogs)
+		r.With(requireScope(auth.ScopeTrafficRead)).Get("/traffic", traffic)
+		r.With(requireScope(auth.ScopeTrafficRead)).Get("/memory", memory)
 		r.Get("/version", version)
+		// /configs, /proxies and /connections mix read and write routes,
+		// so they're mounted unguarded and each handler checks its own
+		// scope via requireScopeInline instead of a single blanket scope
+		// that would require both the mount's and the handler's scope.
 		r.Mount("/configs", configRouter())
 		r.Mount("/proxies", proxyRouter())
-		r.Mount("/rules", ruleRouter())
+		r.With(requireScope(auth.ScopeProxiesRead)).Mount("/group", groupRouter())
+		r.With(requireScope(auth.ScopeRulesRead)).Mount("/rules", ruleRouter())
 		r.Mount("/connections", connectionRouter())
-		r.Mount("/providers/proxies", proxyProviderRouter())
+		r.With(requireScope(auth.ScopeProvidersRead)).Mount("/providers/proxies", proxyProviderRouter())
+		r.With(requireScope(auth.ScopeTunnelConnect)).Get("/tunnel/{host}/{port}", tunnelHandler)
 	})
 
 	if uiPath != "" {
@@ -97,40 +111,6 @@ func Start(addr string, secret string) {
 	}
 }
 
-func authentication(next http.Handler) http.Handler {
-	fn := func(w http.ResponseWriter, r *http.Request) {
-		if serverSecret == "" {
-			next.ServeHTTP(w, r)
-			return
-		}
-
-		// Browser websocket not support custom header
-		if websocket.IsWebSocketUpgrade(r) && r.URL.Query().Get("token") != "" {
-			token := r.URL.Query().Get("token")
-			if token != serverSecret {
-				render.Status(r, http.StatusUnauthorized)
-				render.JSON(w, r, ErrUnauthorized)
-				return
-			}
-			next.ServeHTTP(w, r)
-			return
-		}
-
-		header := r.Header.Get("Authorization")
-		bearer, token, found := strings.Cut(header, " ")
-
-		hasInvalidHeader := bearer != "Bearer"
-		hasInvalidSecret := !found || token != serverSecret
-		if hasInvalidHeader || hasInvalidSecret {
-			render.Status(r, http.StatusUnauthorized)
-			render.JSON(w, r, ErrUnauthorized)
-			return
-		}
-		next.ServeHTTP(w, r)
-	}
-	return http.HandlerFunc(fn)
-}
-
 func traffic(w http.ResponseWriter, r *http.Request) {
 	var wsConn *websocket.Conn
 	if websocket.IsWebSocketUpgrade(r) {
@@ -141,8 +121,14 @@ func traffic(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	sse := wsConn == nil && isSSE(r)
+
 	if wsConn == nil {
-		w.Header().Set("Content-Type", "application/json")
+		if sse {
+			w.Header().Set("Content-Type", "text/event-stream")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
 		render.Status(r, http.StatusOK)
 	}
 
@@ -161,11 +147,14 @@ func traffic(w http.ResponseWriter, r *http.Request) {
 			break
 		}
 
-		if wsConn == nil {
+		switch {
+		case wsConn != nil:
+			err = wsConn.WriteMessage(websocket.TextMessage, buf.Bytes())
+		case sse:
+			err = writeSSE(w, buf.Bytes())
+		default:
 			_, err = w.Write(buf.Bytes())
 			w.(http.Flusher).Flush()
-		} else {
-			err = wsConn.WriteMessage(websocket.TextMessage, buf.Bytes())
 		}
 
 		if err != nil {
@@ -201,8 +190,14 @@ func getLogs(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	sse := wsConn == nil && isSSE(r)
+
 	if wsConn == nil {
-		w.Header().Set("Content-Type", "application/json")
+		if sse {
+			w.Header().Set("Content-Type", "text/event-stream")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
 		render.Status(r, http.StatusOK)
 	}
 
@@ -236,11 +231,14 @@ func getLogs(w http.ResponseWriter, r *http.Request) {
 		}
 
 		var err error
-		if wsConn == nil {
+		switch {
+		case wsConn != nil:
+			err = wsConn.WriteMessage(websocket.TextMessage, buf.Bytes())
+		case sse:
+			err = writeSSE(w, buf.Bytes())
+		default:
 			_, err = w.Write(buf.Bytes())
 			w.(http.Flusher).Flush()
-		} else {
-			err = wsConn.WriteMessage(websocket.TextMessage, buf.Bytes())
 		}
 
 		if err != nil {
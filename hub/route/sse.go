@@ -0,0 +1,28 @@
+package route
+
+import (
+	"net/http"
+	"strings"
+)
+
+// isSSE reports whether the client asked for a text/event-stream
+// response, the fallback /traffic, /logs and /memory offer for browsers
+// behind proxies that strip WebSocket upgrades.
+func isSSE(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// writeSSE writes a single Server-Sent Events message and flushes it.
+func writeSSE(w http.ResponseWriter, data []byte) error {
+	if _, err := w.Write([]byte("data: ")); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte("\n\n")); err != nil {
+		return err
+	}
+	w.(http.Flusher).Flush()
+	return nil
+}
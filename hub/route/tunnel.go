@@ -0,0 +1,92 @@
+package route
+
+import (
+	stdcontext "context"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/Dreamacro/clash/adapter/inbound"
+	"github.com/Dreamacro/clash/log"
+	"github.com/Dreamacro/clash/transport/socks5"
+	"github.com/Dreamacro/clash/transport/upstream"
+	"github.com/Dreamacro/clash/transport/ws"
+	"github.com/Dreamacro/clash/tunnel"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/gorilla/websocket"
+)
+
+var tunnelUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+	Subprotocols: []string{"binary"},
+}
+
+// tunnelHandler upgrades the request to a WebSocket and bridges its
+// binary frames to a ConnContext, exposing every inbound rule/proxy over
+// WebSocket the same way SOCKS/HTTP CONNECT do today. This lets
+// browser-based or restricted-egress clients reach the proxy engine
+// without a local SOCKS/HTTP client.
+func tunnelHandler(w http.ResponseWriter, r *http.Request) {
+	host := chi.URLParam(r, "host")
+	port := chi.URLParam(r, "port")
+	target := net.JoinHostPort(host, port)
+
+	addr := socks5.ParseAddr(target)
+	if addr == nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, ErrBadRequest)
+		return
+	}
+
+	wsConn, err := tunnelUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Warnln("tunnel: upgrade failed: %s", err)
+		return
+	}
+
+	conn := ws.New(wsConn)
+	connCtx := inbound.NewWebSocket(addr, wsConn.RemoteAddr(), conn)
+
+	// tunnel.TCPIn's rule-based dispatcher predates proxy-chain and
+	// doesn't consult it, so a target the chain actually resolves an
+	// upstream for is dialed and relayed right here instead; a
+	// NO_PROXY-bypassed or unconfigured chain falls through to TCPIn as
+	// before.
+	if chain := upstream.Default(); chain != nil {
+		if up, err := chain.Resolve(target, ""); err == nil && up != nil {
+			relayThroughChain(r.Context(), chain, connCtx.Conn(), target)
+			connCtx.Conn().Close()
+			return
+		}
+	}
+
+	// The tunnel closes connCtx's conn when it is done relaying, so the
+	// socket is left open here once handed off.
+	tunnel.TCPIn() <- connCtx
+}
+
+// relayThroughChain dials target through chain and bridges src to it
+// until either side closes the connection.
+func relayThroughChain(ctx stdcontext.Context, chain *upstream.Chain, src net.Conn, target string) {
+	dst, err := chain.DialContext(ctx, "tcp", target, "")
+	if err != nil {
+		log.Warnln("tunnel: dialing %s via upstream: %s", target, err)
+		return
+	}
+	defer dst.Close()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(dst, src)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(src, dst)
+		done <- struct{}{}
+	}()
+	<-done
+}
@@ -0,0 +1,20 @@
+package route
+
+import "github.com/go-chi/render"
+
+// ctxKey namespaces values stored on the request context by this
+// package's middleware (e.g. parseProxyFromCtx), to avoid clashing with
+// keys set elsewhere.
+type ctxKey string
+
+const ctxKeyProxy ctxKey = "proxy"
+
+var (
+	ErrUnauthorized = render.M{"message": "unauthorized"}
+	ErrBadRequest   = render.M{"message": "bad request"}
+	ErrNotFound     = render.M{"message": "not found"}
+)
+
+func newError(message string) render.M {
+	return render.M{"message": message}
+}
@@ -1,7 +1,7 @@
 package auth
 
 import (
-	"github.com/btwiuse/dash/component/auth"
+	"github.com/Dreamacro/clash/component/auth"
 )
 
 var authenticator auth.Authenticator
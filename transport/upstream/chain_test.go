@@ -0,0 +1,151 @@
+package upstream
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+)
+
+// fakeConnectProxy accepts HTTP CONNECT requests, replies 200 OK, and
+// reports the remote address of every connection it accepts so tests
+// can tell distinct dials apart.
+func fakeConnectProxy(t *testing.T) (addr string, accepted chan string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	accepted = make(chan string, 16)
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				req, err := http.ReadRequest(bufio.NewReader(conn))
+				if err != nil {
+					return
+				}
+				_ = req.Body.Close()
+				conn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n"))
+				accepted <- conn.LocalAddr().String() + "<-" + conn.RemoteAddr().String()
+				// Hold the tunnel open briefly so a reused connection
+				// would be observably shared rather than just closed.
+				buf := make([]byte, 1)
+				conn.Read(buf)
+			}(conn)
+		}
+	}()
+
+	t.Cleanup(func() { ln.Close() })
+	return ln.Addr().String(), accepted
+}
+
+// fakeConnectProxyPipelined is like fakeConnectProxy, but writes payload
+// immediately after the CONNECT response in the same conn.Write call,
+// the way a real HTTP proxy (or an eager target behind it) might
+// pipeline bytes onto an already-established tunnel.
+func fakeConnectProxyPipelined(t *testing.T, payload []byte) (addr string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		req, err := http.ReadRequest(bufio.NewReader(conn))
+		if err != nil {
+			return
+		}
+		_ = req.Body.Close()
+		conn.Write(append([]byte("HTTP/1.1 200 Connection Established\r\n\r\n"), payload...))
+	}()
+
+	t.Cleanup(func() { ln.Close() })
+	return ln.Addr().String()
+}
+
+// TestChainDialContextPreservesPipelinedBytes is a regression test for a
+// bug where the bufio.Reader httpConnect used to read the CONNECT
+// response discarded any bytes it had buffered past the response's
+// trailing CRLF: a proxy pipelining the tunneled stream's first bytes
+// right after "200 Connection Established" in one TCP segment had that
+// data silently dropped instead of handed to the conn DialContext
+// returns.
+func TestChainDialContextPreservesPipelinedBytes(t *testing.T) {
+	payload := []byte("pipelined-hello")
+	proxyAddr := fakeConnectProxyPipelined(t, payload)
+
+	chain, err := NewChain("http://"+proxyAddr, "")
+	if err != nil {
+		t.Fatalf("NewChain: %v", err)
+	}
+
+	conn, err := chain.DialContext(context.Background(), "tcp", "example.com:80", "")
+	if err != nil {
+		t.Fatalf("DialContext: %v", err)
+	}
+	defer conn.Close()
+
+	got := make([]byte, len(payload))
+	if _, err := io.ReadFull(conn, got); err != nil {
+		t.Fatalf("ReadFull: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("got %q, want %q", got, payload)
+	}
+}
+
+func TestChainDialContextDoesNotReuseConnections(t *testing.T) {
+	proxyAddr, accepted := fakeConnectProxy(t)
+
+	chain, err := NewChain("http://"+proxyAddr, "")
+	if err != nil {
+		t.Fatalf("NewChain: %v", err)
+	}
+
+	first, err := chain.DialContext(context.Background(), "tcp", "example.com:80", "")
+	if err != nil {
+		t.Fatalf("first DialContext: %v", err)
+	}
+	if err := first.Close(); err != nil {
+		t.Fatalf("first Close: %v", err)
+	}
+
+	second, err := chain.DialContext(context.Background(), "tcp", "example.com:80", "")
+	if err != nil {
+		t.Fatalf("second DialContext: %v", err)
+	}
+	defer second.Close()
+
+	if first == second {
+		t.Fatal("DialContext returned the same connection for two separate dials")
+	}
+
+	var keys []string
+	for i := 0; i < 2; i++ {
+		select {
+		case k := <-accepted:
+			keys = append(keys, k)
+		default:
+			t.Fatalf("proxy only accepted %d connection(s), want 2", i)
+		}
+	}
+	if keys[0] == keys[1] {
+		t.Fatalf("both dials reused the same underlying proxy connection: %v", keys)
+	}
+}
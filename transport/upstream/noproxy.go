@@ -0,0 +1,65 @@
+package upstream
+
+import (
+	"net"
+	"strings"
+)
+
+// NoProxy matches hosts that must bypass the upstream chain, parsed from
+// a NO_PROXY/no-proxy-style comma-separated list of CIDRs and domain
+// suffixes (e.g. "10.0.0.0/8,.internal.example.com").
+type NoProxy struct {
+	raw      string
+	cidrs    []*net.IPNet
+	suffixes []string
+}
+
+// String returns the list NoProxy was parsed from, so callers that
+// rebuild a Chain can carry the bypass list forward unchanged.
+func (n *NoProxy) String() string {
+	if n == nil {
+		return ""
+	}
+	return n.raw
+}
+
+// ParseNoProxy parses list, skipping empty entries.
+func ParseNoProxy(list string) *NoProxy {
+	n := &NoProxy{raw: list}
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			n.cidrs = append(n.cidrs, cidr)
+			continue
+		}
+		n.suffixes = append(n.suffixes, strings.ToLower(strings.TrimPrefix(entry, ".")))
+	}
+	return n
+}
+
+// Match reports whether host should bypass the upstream chain.
+func (n *NoProxy) Match(host string) bool {
+	if n == nil {
+		return false
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		for _, cidr := range n.cidrs {
+			if cidr.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	host = strings.ToLower(host)
+	for _, suffix := range n.suffixes {
+		if host == suffix || strings.HasSuffix(host, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
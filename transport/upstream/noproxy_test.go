@@ -0,0 +1,42 @@
+package upstream
+
+import "testing"
+
+func TestNoProxyMatch(t *testing.T) {
+	n := ParseNoProxy("10.0.0.0/8, .internal.example.com ,localhost")
+
+	cases := []struct {
+		host string
+		want bool
+	}{
+		{"10.1.2.3", true},
+		{"192.168.1.1", false},
+		{"api.internal.example.com", true},
+		{"internal.example.com", true},
+		{"example.com", false},
+		{"localhost", true},
+		{"LOCALHOST", true},
+	}
+	for _, c := range cases {
+		if got := n.Match(c.host); got != c.want {
+			t.Errorf("Match(%q) = %v, want %v", c.host, got, c.want)
+		}
+	}
+}
+
+func TestNoProxyEmptyListMatchesNothing(t *testing.T) {
+	n := ParseNoProxy("")
+	if n.Match("anything.example.com") {
+		t.Error("empty NO_PROXY list matched a host")
+	}
+}
+
+func TestNoProxyNilReceiver(t *testing.T) {
+	var n *NoProxy
+	if n.Match("example.com") {
+		t.Error("nil *NoProxy matched a host")
+	}
+	if n.String() != "" {
+		t.Errorf("nil *NoProxy.String() = %q, want empty", n.String())
+	}
+}
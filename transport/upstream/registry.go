@@ -0,0 +1,22 @@
+package upstream
+
+import "sync/atomic"
+
+// defaultChain is the Chain outbound dialers use when a rule has no
+// via: override, configured globally from proxy-chain: in the config
+// and hot-swappable via PATCH /configs. Default() is read on every
+// inbound connection's dial path while SetDefault() is called
+// concurrently from the PATCH /configs handler, so it's an
+// atomic.Pointer rather than a plain *Chain.
+var defaultChain atomic.Pointer[Chain]
+
+// Default returns the globally configured Chain, or nil if proxy-chain:
+// hasn't been set.
+func Default() *Chain {
+	return defaultChain.Load()
+}
+
+// SetDefault registers the globally configured Chain.
+func SetDefault(c *Chain) {
+	defaultChain.Store(c)
+}
@@ -0,0 +1,32 @@
+package upstream
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestDefaultSetDefaultConcurrentAccess is a regression test for a data
+// race between Default() (read on every inbound connection's dial path)
+// and SetDefault() (called concurrently from PATCH /configs): run under
+// -race, a plain *Chain package variable flags this as a race.
+func TestDefaultSetDefaultConcurrentAccess(t *testing.T) {
+	chain, err := NewChain("http://127.0.0.1:8080", "")
+	if err != nil {
+		t.Fatalf("NewChain: %v", err)
+	}
+	defer SetDefault(nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			SetDefault(chain)
+		}()
+		go func() {
+			defer wg.Done()
+			Default()
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,147 @@
+package upstream
+
+import (
+	"errors"
+	"io"
+	"net"
+)
+
+// socks5Connect performs a SOCKS5 CONNECT handshake for addr over conn,
+// per RFC 1928/1929: no-auth if Username is empty, username/password
+// subnegotiation otherwise.
+func (u *Upstream) socks5Connect(conn net.Conn, addr string) error {
+	methods := []byte{0x00}
+	if u.Username != "" {
+		methods = []byte{0x02}
+	}
+
+	greeting := append([]byte{0x05, byte(len(methods))}, methods...)
+	if _, err := conn.Write(greeting); err != nil {
+		return err
+	}
+
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	if reply[0] != 0x05 {
+		return errors.New("upstream: not a SOCKS5 proxy")
+	}
+
+	switch reply[1] {
+	case 0x00:
+		// no authentication required
+	case 0x02:
+		if err := u.socks5Authenticate(conn); err != nil {
+			return err
+		}
+	default:
+		return errors.New("upstream: SOCKS5 proxy rejected all auth methods")
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+
+	req, err := socks5ConnectRequest(host, port)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Write(req); err != nil {
+		return err
+	}
+
+	return socks5ReadReply(conn)
+}
+
+func (u *Upstream) socks5Authenticate(conn net.Conn) error {
+	buf := []byte{0x01, byte(len(u.Username))}
+	buf = append(buf, u.Username...)
+	buf = append(buf, byte(len(u.Password)))
+	buf = append(buf, u.Password...)
+
+	if _, err := conn.Write(buf); err != nil {
+		return err
+	}
+
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	if reply[1] != 0x00 {
+		return errors.New("upstream: SOCKS5 authentication failed")
+	}
+	return nil
+}
+
+func socks5ConnectRequest(host, port string) ([]byte, error) {
+	req := []byte{0x05, 0x01, 0x00}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			req = append(req, 0x01)
+			req = append(req, ip4...)
+		} else {
+			req = append(req, 0x04)
+			req = append(req, ip.To16()...)
+		}
+	} else {
+		if len(host) > 255 {
+			return nil, errors.New("upstream: host name too long for SOCKS5")
+		}
+		req = append(req, 0x03, byte(len(host)))
+		req = append(req, host...)
+	}
+
+	p, err := parsePort(port)
+	if err != nil {
+		return nil, err
+	}
+	req = append(req, byte(p>>8), byte(p))
+	return req, nil
+}
+
+func socks5ReadReply(conn net.Conn) error {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	if header[1] != 0x00 {
+		return errors.New("upstream: SOCKS5 CONNECT rejected")
+	}
+
+	var addrLen int
+	switch header[3] {
+	case 0x01:
+		addrLen = net.IPv4len
+	case 0x04:
+		addrLen = net.IPv6len
+	case 0x03:
+		lenByte := make([]byte, 1)
+		if _, err := io.ReadFull(conn, lenByte); err != nil {
+			return err
+		}
+		addrLen = int(lenByte[0])
+	default:
+		return errors.New("upstream: unknown SOCKS5 address type in reply")
+	}
+
+	// bound address + port, discarded: callers only care that the tunnel
+	// is established.
+	if _, err := io.CopyN(io.Discard, conn, int64(addrLen+2)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func parsePort(port string) (uint16, error) {
+	var p uint16
+	for _, c := range port {
+		if c < '0' || c > '9' {
+			return 0, errors.New("upstream: invalid port " + port)
+		}
+		p = p*10 + uint16(c-'0')
+	}
+	return p, nil
+}
@@ -0,0 +1,24 @@
+package upstream
+
+import (
+	"context"
+	"testing"
+)
+
+// TestUpstreamHTTPSAttemptsTLSToProxy proves "https://" actually performs
+// a TLS handshake with the upstream proxy instead of treating it like
+// "http://" and dialing it in plaintext: dialed against fakeConnectProxy
+// (a plain-TCP listener), the TLS ClientHello it can't understand must
+// fail the dial rather than succeed as a bare TCP connection.
+func TestUpstreamHTTPSAttemptsTLSToProxy(t *testing.T) {
+	proxyAddr, _ := fakeConnectProxy(t)
+
+	chain, err := NewChain("https://"+proxyAddr, "")
+	if err != nil {
+		t.Fatalf("NewChain: %v", err)
+	}
+
+	if _, err := chain.DialContext(context.Background(), "tcp", "example.com:80", ""); err == nil {
+		t.Fatal("DialContext through an https:// upstream succeeded against a plaintext listener, want a TLS handshake error")
+	}
+}
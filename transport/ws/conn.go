@@ -0,0 +1,63 @@
+// Package ws adapts a gorilla websocket connection to the net.Conn
+// interface so binary WebSocket streams can be handed to code that only
+// knows how to read and write plain connections (e.g. the tunnel).
+package ws
+
+import (
+	"net"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Conn wraps *websocket.Conn as a net.Conn, reading and writing
+// BinaryMessage frames. Reads that don't consume a whole frame are
+// buffered in residual so callers can read in arbitrarily small chunks
+// across frame boundaries.
+type Conn struct {
+	*websocket.Conn
+
+	residual []byte
+}
+
+// New wraps wsConn as a net.Conn.
+func New(wsConn *websocket.Conn) *Conn {
+	return &Conn{Conn: wsConn}
+}
+
+func (c *Conn) Read(b []byte) (int, error) {
+	for len(c.residual) == 0 {
+		msgType, data, err := c.Conn.ReadMessage()
+		if err != nil {
+			return 0, err
+		}
+		if msgType != websocket.BinaryMessage {
+			continue
+		}
+		c.residual = data
+	}
+
+	n := copy(b, c.residual)
+	c.residual = c.residual[n:]
+	return n, nil
+}
+
+func (c *Conn) Write(b []byte) (int, error) {
+	if err := c.Conn.WriteMessage(websocket.BinaryMessage, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (c *Conn) Close() error {
+	return c.Conn.Close()
+}
+
+func (c *Conn) SetDeadline(t time.Time) error {
+	if err := c.Conn.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.Conn.SetWriteDeadline(t)
+}
+
+var _ net.Conn = (*Conn)(nil)
@@ -0,0 +1,95 @@
+package ws
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+var upgrader = websocket.Upgrader{}
+
+// wsPipe returns a connected client/server pair of *Conn, each wrapping
+// a real *websocket.Conn over a loopback httptest server, so tests
+// exercise Conn against actual WebSocket framing rather than a mock.
+func wsPipe(t *testing.T) (client, server *Conn) {
+	t.Helper()
+
+	serverConns := make(chan *websocket.Conn, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wsConn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("server upgrade: %v", err)
+			return
+		}
+		serverConns <- wsConn
+	}))
+	t.Cleanup(srv.Close)
+
+	url := "ws" + srv.URL[len("http"):]
+	clientConn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("client dial: %v", err)
+	}
+	t.Cleanup(func() { clientConn.Close() })
+
+	serverConn := <-serverConns
+	t.Cleanup(func() { serverConn.Close() })
+
+	return New(clientConn), New(serverConn)
+}
+
+// TestConnReadFrameLargerThanReadBuffer proves a single WebSocket binary
+// frame bigger than the caller's read buffer is fully delivered across
+// however many Read calls it takes, with residual correctly retaining
+// and draining the unread tail of the frame.
+func TestConnReadFrameLargerThanReadBuffer(t *testing.T) {
+	client, server := wsPipe(t)
+
+	payload := bytes.Repeat([]byte("0123456789"), 100) // 1000 bytes
+	if _, err := server.Write(payload); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	got := make([]byte, len(payload))
+	buf := make([]byte, 7) // deliberately not a divisor of len(payload)
+	n := 0
+	for n < len(got) {
+		m, err := client.Read(buf)
+		if err != nil {
+			t.Fatalf("Read at offset %d: %v", n, err)
+		}
+		n += copy(got[n:], buf[:m])
+	}
+
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("reassembled payload mismatch")
+	}
+}
+
+// TestConnReadAcrossMultipleFrames proves consecutive Reads correctly
+// call ReadMessage again once residual is drained, so data split across
+// multiple WebSocket frames comes back in order and undivided.
+func TestConnReadAcrossMultipleFrames(t *testing.T) {
+	client, server := wsPipe(t)
+
+	if _, err := server.Write([]byte("hello ")); err != nil {
+		t.Fatalf("Write first frame: %v", err)
+	}
+	if _, err := server.Write([]byte("world")); err != nil {
+		t.Fatalf("Write second frame: %v", err)
+	}
+
+	want := []byte("hello world")
+	got := make([]byte, len(want))
+	if _, err := io.ReadFull(client, got); err != nil {
+		t.Fatalf("ReadFull: %v", err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}